@@ -5,6 +5,7 @@ package amass
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ import (
 	"github.com/OWASP/Amass/amass/utils"
 	"github.com/PuerkitoBio/fetchbot"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/miekg/dns"
 )
 
 // Banner is the ASCII art logo used within help output.
@@ -62,6 +64,7 @@ const (
 	BRUTE   = "brute"
 	CERT    = "cert"
 	DNS     = "dns"
+	DNSAPI  = "dnsapi"
 	SCRAPE  = "scrape"
 )
 
@@ -107,6 +110,9 @@ type Enumeration struct {
 	//flag to print on stdout in json format
 	STDoutJSON bool
 
+	// OutputFormat selects how records delivered on Output should be rendered
+	OutputFormat OutputFormat
+
 	// The writer used to save the data operations performed
 	DataOptsWriter io.Writer
 
@@ -123,12 +129,45 @@ type Enumeration struct {
 	activeCert   *ActiveCertService
 	dataSources  []AmassService
 
+	// resolvers is the pool of pluggable DNS transports selected during CheckConfig
+	resolvers *ResolverPool
+
+	// wildcards is the two-tier Bloom + statistical wildcard detector
+	wildcards *wildcardDetector
+
+	// store persists graph records and admitted name lists across runs once LoadState has
+	// opened it under Config.StateDir
+	store Store
+
+	// hostLimiter enforces a politeness-aware, per-host request budget
+	hostLimiter *HostRateLimiter
+
+	// Changes receives Added/Removed/Changed diffs while running in --monitor mode
+	Changes chan *Change
+
+	// seen mirrors every AmassOutput delivered on Output, keyed by name, so Monitor can
+	// diff one pass against the next and LoadState/persistState can round-trip real state
+	// through Store without reaching into Graph's unexported fields
+	seen     nameSnapshot
+	seenLock sync.Mutex
+
+	// trustedNames/otherNames mirror the names admitted through trustedNameFilter/
+	// otherNameFilter; StringFilter only supports membership tests, not enumeration, so
+	// these plain lists are what persistState actually saves and LoadState reloads
+	trustedNames []string
+	otherNames   []string
+	nameListLock sync.Mutex
+
 	trustedNameFilter *utils.StringFilter
 	otherNameFilter   *utils.StringFilter
 
 	// Pause/Resume channels for halting the enumeration
 	pause  chan struct{}
 	resume chan struct{}
+
+	// monitoring is set by Monitor before it starts looping Start, so Start knows its
+	// caller is going to keep reading Output across multiple passes and must not close it
+	monitoring bool
 }
 
 func init() {
@@ -191,6 +230,22 @@ func (e *Enumeration) CheckConfig() error {
 		e.Config.Wordlist, err = getDefaultWordlist(e.Proxy)
 	}
 
+	pool, rerr := NewResolverPool(e.Config.Resolvers)
+	if rerr != nil {
+		return rerr
+	}
+	if e.Config.Passive {
+		// Passive enumerations should blend in with normal encrypted DNS traffic
+		// rather than leak plain UDP/53 queries to a captive resolver.
+		pool = pool.PassivePreferred()
+	}
+	e.resolvers = pool
+
+	e.hostLimiter = NewHostRateLimiter(e.Config.Timing, e.Config.HostRateLimits)
+
+	e.wildcards = newWildcardDetector(e.Config.WildcardDetection, e.Config.Timing.ToMaxFlow())
+	e.wildcards.resolve = e.sampleWildcardAnswer
+
 	e.MaxFlow = utils.NewTimedSemaphore(
 		e.Config.Timing.ToMaxFlow(),
 		e.Config.Timing.ToReleaseDelay())
@@ -250,11 +305,137 @@ loop:
 	for _, srv := range services {
 		srv.Stop()
 	}
+
+	// Dependency analysis issues its own direct NS/glue/SOA resolution, so it only runs
+	// when the enumeration is allowed to make active DNS queries.
+	if !e.Config.Passive {
+		for _, domain := range e.Config.Domains() {
+			if err := e.AnalyzeDependencies(domain); err != nil {
+				e.Log.Printf("AnalyzeDependencies: %s: %v", domain, err)
+			}
+		}
+	}
+
 	time.Sleep(2 * time.Second)
-	close(e.Output)
+	// Monitor keeps reading Output across repeated passes, so only a one-shot Start
+	// should close it; closing here on every pass would panic the next pass's
+	// OutputEvent with a send on a closed channel.
+	if !e.monitoring {
+		close(e.Output)
+	}
 	return nil
 }
 
+// LoadState reloads persisted graph and name-filter state from Config.StateDir so this
+// enumeration only emits AmassOutput for genuinely new names and addresses rather than
+// everything discovered since the beginning. Graph and StringFilter expose no fields of
+// their own to marshal, so what's actually persisted is the plain AmassOutput/name-list
+// state this package tracks alongside them, replayed back through their public APIs.
+// No DNS answer cache, wildcard decisions or per-source cursors are loaded here.
+func (e *Enumeration) LoadState() error {
+	if e.Config.StateDir == "" {
+		return errors.New("LoadState requires Config.StateDir to be set")
+	}
+
+	store, err := NewFileStore(e.Config.StateDir)
+	if err != nil {
+		return err
+	}
+	e.store = store
+
+	var records []*AmassOutput
+	if _, err := e.store.Load(stateKeyGraph, &records); err != nil {
+		return err
+	}
+	for _, out := range records {
+		e.Graph.InsertRecord(out)
+		e.recordSeen(out)
+	}
+
+	var trusted []string
+	if _, err := e.store.Load(stateKeyTrustedNames, &trusted); err != nil {
+		return err
+	}
+	for _, name := range trusted {
+		e.trustedNameFilter.Duplicate(name)
+	}
+	e.nameListLock.Lock()
+	e.trustedNames = append(e.trustedNames, trusted...)
+	e.nameListLock.Unlock()
+
+	var other []string
+	if _, err := e.store.Load(stateKeyOtherNames, &other); err != nil {
+		return err
+	}
+	for _, name := range other {
+		e.otherNameFilter.Duplicate(name)
+	}
+	e.nameListLock.Lock()
+	e.otherNames = append(e.otherNames, other...)
+	e.nameListLock.Unlock()
+	return nil
+}
+
+// Monitor runs the enumeration repeatedly on the given interval, diffing each pass's
+// snapshot of seen names against the previous one and emitting Added/Removed/Changed
+// events on e.Changes instead of re-reporting names that were already seen.
+func (e *Enumeration) Monitor(interval time.Duration) error {
+	if err := e.LoadState(); err != nil {
+		return err
+	}
+	e.Changes = make(chan *Change, 100)
+	e.monitoring = true
+
+	for {
+		before := e.copySnapshot()
+
+		if err := e.Start(); err != nil {
+			return err
+		}
+
+		for _, c := range diffSnapshots(before, e.copySnapshot()) {
+			e.Changes <- c
+		}
+		if err := e.persistState(); err != nil {
+			e.Log.Printf("Monitor: failed to persist state: %v", err)
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (e *Enumeration) persistState() error {
+	if e.store == nil {
+		return nil
+	}
+
+	snap := e.copySnapshot()
+	records := make([]*AmassOutput, 0, len(snap))
+	for _, out := range snap {
+		records = append(records, out)
+	}
+	if err := e.store.Save(stateKeyGraph, records); err != nil {
+		return err
+	}
+
+	e.nameListLock.Lock()
+	trusted := append([]string{}, e.trustedNames...)
+	other := append([]string{}, e.otherNames...)
+	e.nameListLock.Unlock()
+
+	if err := e.store.Save(stateKeyTrustedNames, trusted); err != nil {
+		return err
+	}
+	return e.store.Save(stateKeyOtherNames, other)
+}
+
+// Resolvers returns the pool of DNS transports selected for this enumeration. Dependency
+// analysis and wildcard sampling query through it; DNSService, including ReverseDNSSweep,
+// does not use this pool.
+func (e *Enumeration) Resolvers() *ResolverPool {
+	return e.resolvers
+}
+
 // Pause temporarily halts the enumeration.
 func (e *Enumeration) Pause() {
 	e.pause <- struct{}{}
@@ -294,6 +475,7 @@ func (e *Enumeration) NewNameEvent(req *AmassRequest) {
 	} else if tt && e.trustedNameFilter.Duplicate(req.Name) {
 		return
 	}
+	e.recordName(tt, req.Name)
 
 	if !e.Config.Passive {
 		e.MaxFlow.Acquire(1)
@@ -335,8 +517,11 @@ func (e *Enumeration) ResolveNameEvent(req *AmassRequest) {
 		return
 	}
 
+	// The cached per-parent wildcard decision short-circuits before the name is resolved; the
+	// first name under an unseen parent runs the sampler, which resolves random labels. The
+	// Bloom filter of answer hashes is only checked after resolution, in ResolvedNameEvent.
 	if e.Config.Blacklisted(req.Name) || (!TrustedTag(req.Tag) &&
-		e.dnsService.GetWildcardType(req) == WildcardTypeDynamic) {
+		e.wildcards.GetWildcardType(req, parentOf(req.Name, req.Domain)) == WildcardTypeDynamic) {
 		if !e.Config.Passive {
 			e.MaxFlow.Release(1)
 		}
@@ -347,12 +532,44 @@ func (e *Enumeration) ResolveNameEvent(req *AmassRequest) {
 
 // ResolvedNameEvent signals the NameService of a newly resolved DNS name.
 func (e *Enumeration) ResolvedNameEvent(req *AmassRequest) {
-	if !TrustedTag(req.Tag) && e.dnsService.MatchesWildcard(req) {
+	if !TrustedTag(req.Tag) && e.wildcards.MatchesWildcard(req, AnswerHash([]string{req.Address}, "")) {
 		return
 	}
 	go e.nameService.Resolved(req)
 }
 
+// sampleWildcardAnswer resolves label.zone through the resolver pool and hashes the
+// resulting answer set, serving as the wildcardDetector's resolve callback.
+func (e *Enumeration) sampleWildcardAnswer(zone, label string) ([]string, string, bool) {
+	r := e.resolvers.Next()
+	if r == nil {
+		return nil, "", false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolverTimeout)
+	defer cancel()
+
+	msg, err := r.Resolve(ctx, label+"."+zone, dns.TypeA)
+	if err != nil || msg == nil {
+		return nil, "", false
+	}
+
+	var ips []string
+	var cname string
+	for _, rr := range msg.Answer {
+		switch v := rr.(type) {
+		case *dns.A:
+			ips = append(ips, v.A.String())
+		case *dns.CNAME:
+			cname = v.Target
+		}
+	}
+	if len(ips) == 0 && cname == "" {
+		return nil, "", false
+	}
+	return ips, AnswerHash(ips, cname), true
+}
+
 // CheckedNameEvent signals all services interested in acting on new validated DNS names.
 func (e *Enumeration) CheckedNameEvent(req *AmassRequest) {
 	go e.dataService.SendRequest(req)
@@ -390,6 +607,7 @@ func (e *Enumeration) ActiveCertEvent(req *AmassRequest) {
 
 // OutputEvent sends enumeration output to the package API caller.
 func (e *Enumeration) OutputEvent(out *AmassOutput) {
+	e.recordSeen(out)
 	e.Output <- out
 }
 
@@ -465,6 +683,14 @@ func (t EnumerationTiming) ToReleasesPerSecond() int {
 	return result
 }
 
+// RequestWebPage routes a GET through the per-host rate limiter before delegating to
+// utils.DefaultClient.RequestWebPage, whose own per-host limiter backs off and retries on
+// 429/5xx responses; e.hostLimiter's bucket is not penalized on those responses.
+func (e *Enumeration) RequestWebPage(url string, hdr map[string]string, cookies []*http.Cookie) (string, error) {
+	e.hostLimiter.Wait(url)
+	return utils.DefaultClient.RequestWebPage(url, hdr, cookies, "", "", e.Proxy)
+}
+
 func getDefaultWordlist(proxy_url string) ([]string, error) {
 	var list []string
 
@@ -491,29 +717,39 @@ func GetAllSources(e *Enumeration) []AmassService {
 		NewArchiveToday(e),
 		NewArquivo(e),
 		NewAsk(e),
+		NewAzureDNS(e),
 		NewBaidu(e),
 		NewBing(e),
 		NewCensys(e),
 		NewCertDB(e),
 		NewCertSpotter(e),
+		NewCloudflareDNS(e),
 		NewCommonCrawl(e),
 		NewCrtsh(e),
+		NewCTLogs(e),
+		NewCTStream(e),
+		NewDigitalOceanDNS(e),
 		//NewDNSDB(e),
 		NewDNSDumpster(e),
+		NewDNSimple(e),
 		NewDNSTable(e),
 		NewDogpile(e),
 		NewEntrust(e),
 		NewExalead(e),
 		NewFindSubdomains(e),
+		NewGandiDNS(e),
 		NewGoogle(e),
+		NewGoogleCloudDNS(e),
 		NewHackerTarget(e),
 		NewIPv4Info(e),
 		NewLoCArchive(e),
 		NewNetcraft(e),
+		NewNS1(e),
 		NewOpenUKArchive(e),
 		NewPTRArchive(e),
 		NewRiddler(e),
 		NewRobtex(e),
+		NewRoute53(e),
 		NewSiteDossier(e),
 		NewThreatCrowd(e),
 		NewUKGovArchive(e),
@@ -572,7 +808,7 @@ func crawl(service AmassService, base, domain, sub string) ([]string, error) {
 	f := fetchbot.New(fetchbot.HandlerFunc(func(ctx *fetchbot.Context, res *http.Response, err error) {
 		mux.Handle(ctx, res, err)
 	}))
-	setFetcherConfig(f)
+	setFetcherConfig(f, service.Enum().Config.Timing.ToHostRate())
 
 	q := f.Start()
 	u := fmt.Sprintf("%s/%s/%s", base, year, sub)
@@ -629,7 +865,7 @@ func linksAndNames(domain string, ctx *fetchbot.Context, res *http.Response, lin
 	return nil
 }
 
-func setFetcherConfig(f *fetchbot.Fetcher) {
+func setFetcherConfig(f *fetchbot.Fetcher, hostRate float64) {
 	d := net.Dialer{}
 	f.HttpClient = &http.Client{
 		Timeout: 10 * time.Second,
@@ -641,7 +877,7 @@ func setFetcherConfig(f *fetchbot.Fetcher) {
 			ExpectContinueTimeout: 5 * time.Second,
 		},
 	}
-	f.CrawlDelay = 1 * time.Second
+	f.CrawlDelay = time.Duration(float64(time.Second) / hostRate)
 	f.DisablePoliteness = true
 	f.UserAgent = utils.UserAgent
 }
@@ -72,27 +72,45 @@ func (c *Crtsh) startRootDomains() {
 	}
 }
 
+// executeQuery runs a single crt.sh query for domain. crt.sh's output=json endpoint has no
+// working pagination parameter (iLast/offset-style params are silently ignored and just
+// return the same rows again), so a domain with enough certificates to hit crt.sh's own
+// response cap will have some names missed; there is no bypass for that here.
 func (c *Crtsh) executeQuery(domain string) {
 	url := c.getURL(domain)
-	page, err := utils.RequestWebPage(url, nil, nil, "", "", c.Enum().Proxy)
+
+	page, err := utils.DefaultClient.RequestWebPage(url, nil, nil, "", "", c.Enum().Proxy)
 	if err != nil {
 		c.Enum().Log.Printf("%s: %s: %v", c.String(), url, err)
 		return
 	}
-
 	c.SetActive()
+	c.processPage(domain, page)
+}
+
+// processPage decodes a crt.sh JSON response and emits a NewNameEvent for every distinct
+// name found.
+func (c *Crtsh) processPage(domain, page string) {
+	seen := make(map[string]bool)
+
 	lines := json.NewDecoder(strings.NewReader(page))
 	for {
 		var line crtData
 		if err := lines.Decode(&line); err == io.EOF {
 			break
 		} else if err != nil {
-			c.Enum().Log.Printf("%s: %s: %v", c.String(), url, err)
+			c.Enum().Log.Printf("%s: %v", c.String(), err)
+			continue
+		}
+
+		name := cleanName(line.Name)
+		if name == "" || seen[name] {
 			continue
 		}
+		seen[name] = true
 
 		c.Enum().NewNameEvent(&AmassRequest{
-			Name:   cleanName(line.Name),
+			Name:   name,
 			Domain: domain,
 			Tag:    c.SourceType,
 			Source: c.String(),
@@ -101,5 +119,5 @@ func (c *Crtsh) executeQuery(domain string) {
 }
 
 func (c *Crtsh) getURL(domain string) string {
-	return "https://crt.sh/?q=%25." + domain + "&output=json"
+	return "https://crt.sh/?q=%25." + domain + "&output=json&exclude=expired&deduplicate=Y"
 }
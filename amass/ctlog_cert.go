@@ -0,0 +1,94 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/OWASP/Amass/amass/utils"
+)
+
+// ctLogEntryType mirrors RFC 6962 §3.1's LogEntryType enum, the value that selects how the
+// rest of a TimestampedEntry is framed.
+type ctLogEntryType uint16
+
+const (
+	ctX509Entry    ctLogEntryType = 0
+	ctPrecertEntry ctLogEntryType = 1
+)
+
+// parseMerkleLeaf decodes the MerkleTreeLeaf/TimestampedEntry framing described in RFC 6962
+// §3.4 out of a get-entries leaf_input, returning the entry type and the raw cert bytes it
+// carries (the leaf certificate for an x509_entry, or the unparseable bare TBSCertificate for
+// a precert_entry — callers need extra_data's pre_certificate to get a parseable cert in that
+// case).
+func parseMerkleLeaf(leafInput []byte) (ctLogEntryType, []byte, error) {
+	// version(1) + leaf_type(1) + timestamp(8) + entry_type(2) precede the signed_entry.
+	if len(leafInput) < 12 {
+		return 0, nil, fmt.Errorf("leaf_input too short: %d bytes", len(leafInput))
+	}
+
+	entryType := ctLogEntryType(binary.BigEndian.Uint16(leafInput[10:12]))
+	rest := leafInput[12:]
+
+	switch entryType {
+	case ctX509Entry:
+		cert, err := readASN1Cert(rest)
+		return entryType, cert, err
+	case ctPrecertEntry:
+		// issuer_key_hash(32) precedes the length-prefixed TBSCertificate
+		if len(rest) < 32 {
+			return entryType, nil, fmt.Errorf("precert entry too short")
+		}
+		cert, err := readASN1Cert(rest[32:])
+		return entryType, cert, err
+	default:
+		return entryType, nil, fmt.Errorf("unknown log entry type %d", entryType)
+	}
+}
+
+// readASN1Cert reads one RFC 6962 "opaque ASN1Cert<1..2^24-1>": a 3-byte big-endian length
+// prefix followed by that many bytes of DER.
+func readASN1Cert(b []byte) ([]byte, error) {
+	if len(b) < 3 {
+		return nil, fmt.Errorf("truncated ASN1Cert length")
+	}
+	n := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	if len(b) < 3+n {
+		return nil, fmt.Errorf("truncated ASN1Cert body")
+	}
+	return b[3 : 3+n], nil
+}
+
+// certFromLeaf parses the actual certificate carried by a get-entries leaf, following the
+// precert_entry indirection through extra_data when needed.
+func certFromLeaf(leafInput, extraData []byte) (*x509.Certificate, error) {
+	entryType, certDER, err := parseMerkleLeaf(leafInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if entryType == ctPrecertEntry {
+		// leafInput only carries the bare TBSCertificate, which has no signature wrapper
+		// and can't be parsed on its own; extra_data's PrecertChainEntry carries the
+		// actual signed pre-certificate (poison extension included) in its place.
+		pre, err := readASN1Cert(extraData)
+		if err != nil {
+			return nil, err
+		}
+		certDER = pre
+	}
+	return x509.ParseCertificate(certDER)
+}
+
+// namesFromCert returns the certificate's CN plus every dNSName SAN, deduplicated.
+func namesFromCert(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return utils.UniqueAppend(names, cert.DNSNames...)
+}
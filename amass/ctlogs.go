@@ -0,0 +1,225 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/OWASP/Amass/amass/utils"
+)
+
+// CTLog describes one RFC 6962 log this service talks to directly.
+type CTLog struct {
+	Name         string
+	URL          string
+	MaxBatchSize int64
+}
+
+// defaultCTLogs is the curated, built-in list of production logs that NewCTLogs assigns to
+// CTLogs.Logs. No Config option adds logs to it or removes logs from it.
+var defaultCTLogs = []CTLog{
+	{Name: "Google Argon2024", URL: "https://ct.googleapis.com/logs/argon2024", MaxBatchSize: 256},
+	{Name: "Google Xenon2024", URL: "https://ct.googleapis.com/logs/xenon2024", MaxBatchSize: 256},
+	{Name: "Cloudflare Nimbus2024", URL: "https://ct.cloudflare.com/logs/nimbus2024", MaxBatchSize: 256},
+	{Name: "DigiCert Yeti2024", URL: "https://yeti2024.ct.digicert.com/log", MaxBatchSize: 256},
+	{Name: "Sectigo Sabre2024", URL: "https://sabre2024h1.ct.sectigo.com", MaxBatchSize: 256},
+}
+
+// CTLogs is the AmassService that queries Certificate Transparency logs over their RFC
+// 6962 HTTP API directly, rather than through crt.sh's web UI.
+type CTLogs struct {
+	BaseAmassService
+
+	SourceType string
+	Logs       []CTLog
+}
+
+// NewCTLogs returns the object initialized, but not yet started.
+func NewCTLogs(e *Enumeration) *CTLogs {
+	c := &CTLogs{SourceType: CERT, Logs: defaultCTLogs}
+
+	c.BaseAmassService = *NewBaseAmassService(e, "CTLogs", c)
+	return c
+}
+
+// OnStart implements the AmassService interface
+func (c *CTLogs) OnStart() error {
+	c.BaseAmassService.OnStart()
+
+	for _, log := range c.Logs {
+		go c.watchLog(log)
+	}
+	go c.processRequests()
+	return nil
+}
+
+// OnStop implements the AmassService interface
+func (c *CTLogs) OnStop() error {
+	c.BaseAmassService.OnStop()
+	return nil
+}
+
+func (c *CTLogs) processRequests() {
+	for {
+		select {
+		case <-c.PauseChan():
+			<-c.ResumeChan()
+		case <-c.Quit():
+			return
+		case <-c.RequestChan():
+			// This data source just throws away the checked DNS names
+			c.SetActive()
+		}
+	}
+}
+
+// watchLog fetches the log's current signed tree head, then pages forward through
+// get-entries in MaxBatchSize chunks, oldest first, from the last persisted index up to that
+// head. It does not page backward from the head.
+func (c *CTLogs) watchLog(log CTLog) {
+	MaxConnections.Acquire(1)
+	defer MaxConnections.Release(1)
+
+	size, err := c.treeSize(log)
+	if err != nil {
+		c.Enum().Log.Printf("%s: %s: %v", c.String(), log.Name, err)
+		return
+	}
+
+	// On first run there's no persisted index to resume from; seed the cursor ctLogsHistoryWindow
+	// entries behind the current head (clamped to 0) so this run actually discovers existing
+	// subdomains instead of only ones added after startup, without paging through the entire
+	// production log. Later runs resume from the persisted index and only fetch new leaves.
+	start := c.loadIndex(log, size)
+	for start < size {
+		end := start + log.MaxBatchSize - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		entries, err := c.getEntries(log, start, end)
+		if err != nil {
+			c.Enum().Log.Printf("%s: %s: %v", c.String(), log.Name, err)
+			break
+		}
+
+		c.SetActive()
+		for _, leaf := range entries {
+			for _, name := range c.namesFromEntry(leaf) {
+				for _, domain := range c.Enum().Config.Domains() {
+					if c.Enum().Config.DomainRegex(domain).MatchString(name) {
+						c.Enum().NewNameEvent(&AmassRequest{
+							Name:   cleanName(name),
+							Domain: domain,
+							Tag:    c.SourceType,
+							Source: c.String(),
+						})
+					}
+				}
+			}
+		}
+
+		start = end + 1
+		c.saveIndex(log, start)
+	}
+}
+
+type ctlogSTHResp struct {
+	TreeSize int64 `json:"tree_size"`
+}
+
+func (c *CTLogs) treeSize(log CTLog) (int64, error) {
+	page, err := utils.RequestWebPage(log.URL+"/ct/v1/get-sth", nil, nil, "", "", c.Enum().Proxy)
+	if err != nil {
+		return 0, err
+	}
+
+	sth := new(ctlogSTHResp)
+	if err := json.Unmarshal([]byte(page), sth); err != nil {
+		return 0, err
+	}
+	return sth.TreeSize, nil
+}
+
+type merkleTreeLeaf struct {
+	LeafInput string `json:"leaf_input"`
+	ExtraData string `json:"extra_data"`
+}
+
+type ctlogEntriesResp struct {
+	Entries []merkleTreeLeaf `json:"entries"`
+}
+
+func (c *CTLogs) getEntries(log CTLog, start, end int64) ([]merkleTreeLeaf, error) {
+	url := fmt.Sprintf("%s/ct/v1/get-entries?start=%d&end=%d", log.URL, start, end)
+	page, err := utils.RequestWebPage(url, nil, nil, "", "", c.Enum().Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(ctlogEntriesResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// namesFromEntry decodes a MerkleTreeLeaf/TimestampedEntry pair, handling both the
+// x509_entry and precert_entry cases (the latter via extra_data's pre_certificate), and
+// returns the CN plus every dNSName SAN found.
+func (c *CTLogs) namesFromEntry(leaf merkleTreeLeaf) []string {
+	input, err := base64.StdEncoding.DecodeString(leaf.LeafInput)
+	if err != nil {
+		return nil
+	}
+	extra, err := base64.StdEncoding.DecodeString(leaf.ExtraData)
+	if err != nil {
+		return nil
+	}
+
+	cert, err := certFromLeaf(input, extra)
+	if err != nil {
+		return nil
+	}
+	return namesFromCert(cert)
+}
+
+func (c *CTLogs) indexPath(log CTLog) string {
+	dir := filepath.Join(c.Enum().Config.ConfigDir(), "ctlogs")
+	name := base64.RawURLEncoding.EncodeToString([]byte(log.URL)) + ".idx"
+	return filepath.Join(dir, name)
+}
+
+// ctLogsHistoryWindow bounds how far behind the current head a first run starts fetching
+// from: far enough to surface existing subdomains the log already carries, without paging
+// all the way back through years of a production log's history.
+const ctLogsHistoryWindow = 50000
+
+// loadIndex returns the last saved entry index for log, or headSize minus ctLogsHistoryWindow
+// (clamped to 0) when none has been saved yet.
+func (c *CTLogs) loadIndex(log CTLog, headSize int64) int64 {
+	data, err := ioutil.ReadFile(c.indexPath(log))
+	if err != nil || len(data) < 8 {
+		if headSize > ctLogsHistoryWindow {
+			return headSize - ctLogsHistoryWindow
+		}
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(data))
+}
+
+func (c *CTLogs) saveIndex(log CTLog, idx int64) {
+	path := c.indexPath(log)
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(idx))
+	ioutil.WriteFile(path, buf, 0644)
+}
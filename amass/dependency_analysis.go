@@ -0,0 +1,130 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"context"
+	"time"
+
+	"github.com/OWASP/Amass/amass/deptree"
+	"github.com/miekg/dns"
+)
+
+const graphResolveTimeout = 3 * time.Second
+
+// DependencyReport is the AmassOutput variant carrying the findings of a dependency
+// tree analysis for a single enumerated name.
+type DependencyReport struct {
+	Name          string
+	Cycles        [][]string
+	CriticalNodes []string
+	Concentration map[string]int
+	GraphViz      string
+}
+
+// graphResolver adapts the enumeration's resolver pool and DNS service to the
+// deptree.Resolver interface so the dependency tree can be walked without duplicating
+// any resolution logic.
+type graphResolver struct {
+	enum *Enumeration
+}
+
+func (g *graphResolver) ZoneCut(name string) (string, []string, error) {
+	pool := g.enum.Resolvers()
+	r := pool.Next()
+
+	ctx, cancel := contextWithTimeout()
+	defer cancel()
+
+	resp, err := r.Resolve(ctx, name, dns.TypeNS)
+	if err != nil || resp == nil {
+		return name, nil, err
+	}
+
+	var nss []string
+	for _, a := range append(resp.Answer, resp.Ns...) {
+		if ns, ok := a.(*dns.NS); ok {
+			nss = append(nss, cleanName(ns.Ns))
+		}
+	}
+	return name, nss, nil
+}
+
+func (g *graphResolver) Resolve(name string) ([]string, bool, error) {
+	pool := g.enum.Resolvers()
+	r := pool.Next()
+
+	ctx, cancel := contextWithTimeout()
+	defer cancel()
+
+	resp, err := r.Resolve(ctx, name, dns.TypeA)
+	if err != nil || resp == nil {
+		return nil, false, err
+	}
+
+	var addrs []string
+	followed := false
+	for _, a := range resp.Answer {
+		switch v := a.(type) {
+		case *dns.A:
+			addrs = append(addrs, v.A.String())
+		case *dns.CNAME:
+			followed = true
+		}
+	}
+	return addrs, followed, nil
+}
+
+func (g *graphResolver) ASN(addr string) (string, error) {
+	if _, _, asn, err := IPRequest(addr); err == nil {
+		return asn, nil
+	}
+	return "", nil
+}
+
+func (g *graphResolver) SOAMinimum(zone string) (time.Duration, error) {
+	pool := g.enum.Resolvers()
+	r := pool.Next()
+
+	ctx, cancel := contextWithTimeout()
+	defer cancel()
+
+	resp, err := r.Resolve(ctx, zone, dns.TypeSOA)
+	if err != nil || resp == nil {
+		return time.Hour, err
+	}
+	for _, a := range resp.Answer {
+		if soa, ok := a.(*dns.SOA); ok {
+			return time.Duration(soa.Minttl) * time.Second, nil
+		}
+	}
+	return time.Hour, nil
+}
+
+// AnalyzeDependencies builds the name→CNAME→NS→IP dependency tree for name and emits the
+// findings through OutputEvent.
+func (e *Enumeration) AnalyzeDependencies(name string) error {
+	tree := deptree.New(name, &graphResolver{enum: e})
+	if err := tree.Build(); err != nil {
+		return err
+	}
+
+	findings := tree.Analyze()
+	e.OutputEvent(&AmassOutput{
+		Name: name,
+		Tag:  DNS,
+		Dependencies: &DependencyReport{
+			Name:          name,
+			Cycles:        findings.Cycles,
+			CriticalNodes: findings.CriticalNodes,
+			Concentration: findings.Concentration,
+			GraphViz:      tree.GraphViz(),
+		},
+	})
+	return nil
+}
+
+func contextWithTimeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), graphResolveTimeout)
+}
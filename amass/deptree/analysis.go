@@ -0,0 +1,232 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package deptree
+
+import "fmt"
+
+// Findings is the report produced by analyzing a built Tree.
+type Findings struct {
+	Cycles        [][]string
+	CriticalNodes []string
+	Concentration map[string]int
+}
+
+// Analyze runs cycle detection, critical-node analysis, and IP/ASN concentration over an
+// already-built Tree.
+func (t *Tree) Analyze() *Findings {
+	return &Findings{
+		Cycles:        t.DetectCycles(),
+		CriticalNodes: t.CriticalNodes(),
+		Concentration: t.asnConcentration(),
+	}
+}
+
+// DetectCycles walks the tree with a DFS visiting-set, returning every cross-zone cycle found.
+func (t *Tree) DetectCycles() [][]string {
+	adj := t.adjacency()
+
+	var cycles [][]string
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+	var stack []string
+
+	var dfs func(n string)
+	dfs = func(n string) {
+		visiting[n] = true
+		stack = append(stack, n)
+
+		for _, next := range adj[n] {
+			if visiting[next] {
+				cycles = append(cycles, cycleFrom(stack, next))
+				continue
+			}
+			if !visited[next] {
+				dfs(next)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		visiting[n] = false
+		visited[n] = true
+	}
+
+	for n := range t.nodes {
+		if !visited[n] {
+			dfs(n)
+		}
+	}
+	return cycles
+}
+
+func cycleFrom(stack []string, back string) []string {
+	for i, n := range stack {
+		if n == back {
+			cycle := append([]string{}, stack[i:]...)
+			return append(cycle, back)
+		}
+	}
+	return append([]string{}, stack...)
+}
+
+// CriticalNodes returns nodes whose removal would disconnect t.root from every path to an
+// authoritative answer, computed via dominator analysis on the DAG rooted at t.root.
+func (t *Tree) CriticalNodes() []string {
+	adj := t.adjacency()
+	order := t.reversePostorder(adj)
+	if len(order) == 0 {
+		return nil
+	}
+
+	idom := make(map[string]string)
+	idom[t.root] = t.root
+
+	idx := make(map[string]int, len(order))
+	for i, n := range order {
+		idx[n] = i
+	}
+	preds := t.predecessors(adj)
+
+	changed := true
+	for changed {
+		changed = false
+		for _, n := range order {
+			if n == t.root {
+				continue
+			}
+
+			var newIdom string
+			for _, p := range preds[n] {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if newIdom == "" {
+					newIdom = p
+					continue
+				}
+				newIdom = intersect(newIdom, p, idom, idx)
+			}
+
+			if newIdom != "" && idom[n] != newIdom {
+				idom[n] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	var critical []string
+	for n, d := range idom {
+		if n != t.root && d != n {
+			critical = append(critical, d)
+		}
+	}
+	return dedupe(critical)
+}
+
+func intersect(a, b string, idom map[string]string, idx map[string]int) string {
+	for a != b {
+		for idx[a] > idx[b] {
+			a = idom[a]
+		}
+		for idx[b] > idx[a] {
+			b = idom[b]
+		}
+	}
+	return a
+}
+
+func (t *Tree) reversePostorder(adj map[string][]string) []string {
+	visited := make(map[string]bool)
+	var order []string
+
+	var dfs func(n string)
+	dfs = func(n string) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		for _, next := range adj[n] {
+			dfs(next)
+		}
+		order = append(order, n)
+	}
+	dfs(t.root)
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+func (t *Tree) predecessors(adj map[string][]string) map[string][]string {
+	preds := make(map[string][]string)
+	for from, tos := range adj {
+		for _, to := range tos {
+			preds[to] = append(preds[to], from)
+		}
+	}
+	return preds
+}
+
+func (t *Tree) adjacency() map[string][]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	adj := make(map[string][]string)
+	for _, e := range t.edges {
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+	return adj
+}
+
+func (t *Tree) asnConcentration() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, n := range t.nodes {
+		if n.ASN != "" {
+			counts[n.ASN]++
+		}
+	}
+	return counts
+}
+
+func dedupe(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	var out []string
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// GraphViz renders the dependency tree in the DOT language for visualization.
+func (t *Tree) GraphViz() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := "digraph deptree {\n"
+	for _, e := range t.edges {
+		out += fmt.Sprintf("\t%q -> %q [label=%q];\n", e.From, e.To, relName(e.Rel))
+	}
+	out += "}\n"
+	return out
+}
+
+func relName(r Relation) string {
+	switch r {
+	case NSOf:
+		return "ns-of"
+	case GlueFor:
+		return "glue-for"
+	case CNameTo:
+		return "cname-to"
+	case ResolvesTo:
+		return "resolves-to"
+	}
+	return "unknown"
+}
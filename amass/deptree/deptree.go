@@ -0,0 +1,231 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package deptree builds and analyzes the name/NS/IP dependency graph behind an
+// enumerated domain, surfacing single points of failure and cross-zone cycles.
+package deptree
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Relation identifies how two nodes in the tree are related.
+type Relation int
+
+// The typed edges that can appear between two nodes of the tree.
+const (
+	NSOf Relation = iota
+	GlueFor
+	CNameTo
+	ResolvesTo
+)
+
+// Node is a single entity discovered while walking the dependency tree: a name, the zone
+// it is authoritative for or lives within, and (once resolved) its IP/ASN.
+type Node struct {
+	Name string
+	Zone string
+	IP   string
+	ASN  string
+}
+
+// Edge is a typed, directed relation between two nodes, keyed by node name.
+type Edge struct {
+	From string
+	To   string
+	Rel  Relation
+}
+
+// Violation records an RFC violation noticed while walking the tree, e.g. an NS name that
+// itself requires following a CNAME to resolve.
+type Violation struct {
+	Name   string
+	Reason string
+}
+
+// Resolver is the minimal set of DNS operations deptree needs in order to walk a zone's
+// dependency tree. Tree caches ZoneCut and Resolve results itself, keeping failures for the
+// SOA minimum TTL of the tree's root zone.
+type Resolver interface {
+	// ZoneCut returns the authoritative NS names for the zone cut covering name.
+	ZoneCut(name string) (zone string, nameservers []string, err error)
+
+	// Resolve returns the IP addresses for name, following any CNAME chain. followed
+	// reports whether a CNAME was traversed to reach the addresses.
+	Resolve(name string) (addrs []string, followed bool, err error)
+
+	// ASN returns the autonomous system number that announces the given address.
+	ASN(addr string) (string, error)
+
+	// SOAMinimum returns the negative-caching TTL advertised by the zone's SOA record.
+	SOAMinimum(zone string) (time.Duration, error)
+}
+
+type cacheKey struct {
+	name  string
+	qtype string
+}
+
+type cacheEntry struct {
+	value    interface{}
+	err      error
+	negative bool
+	expires  time.Time
+}
+
+// Tree is the dependency graph rooted at a single enumerated name.
+type Tree struct {
+	root     string
+	resolver Resolver
+
+	mu         sync.Mutex
+	nodes      map[string]*Node
+	edges      []*Edge
+	Violations []*Violation
+
+	cacheMu sync.Mutex
+	cache   map[cacheKey]*cacheEntry
+}
+
+// New returns a Tree ready to be built for the given root name.
+func New(root string, r Resolver) *Tree {
+	return &Tree{
+		root:     root,
+		resolver: r,
+		nodes:    make(map[string]*Node),
+		cache:    make(map[cacheKey]*cacheEntry),
+	}
+}
+
+// Build walks the zone cuts from the root of the DNS hierarchy down to t.root, recording
+// every NS, glue, CNAME, and resolves-to edge it discovers along the way.
+func (t *Tree) Build() error {
+	labels := strings.Split(strings.TrimSuffix(t.root, "."), ".")
+
+	for i := len(labels) - 1; i >= 0; i-- {
+		zone := strings.Join(labels[i:], ".")
+
+		nss, err := t.zoneCut(zone)
+		if err != nil {
+			return fmt.Errorf("zone cut for %s: %v", zone, err)
+		}
+
+		t.addNode(&Node{Name: zone, Zone: zone})
+		for _, ns := range nss {
+			t.addNode(&Node{Name: ns, Zone: zone})
+			// zone depends on ns, not the other way around: resolving zone requires
+			// reaching one of its nameservers, so the edge must point out of zone (and,
+			// on the final pass where zone == t.root, out of t.root) for CriticalNodes'
+			// dominator walk to have anywhere to go.
+			t.addEdge(zone, ns, NSOf)
+
+			addrs, followed, err := t.resolve(ns)
+			if err != nil {
+				continue
+			}
+			if followed {
+				t.Violations = append(t.Violations, &Violation{
+					Name:   ns,
+					Reason: "nameserver name resolves via CNAME, which RFC 1034/2181 discourage",
+				})
+				t.addEdge(ns, ns+"+cname", CNameTo)
+			}
+			for _, addr := range addrs {
+				asn, _ := t.resolver.ASN(addr)
+				t.addNode(&Node{Name: addr, Zone: zone, IP: addr, ASN: asn})
+				t.addEdge(ns, addr, ResolvesTo)
+
+				if !inBailiwick(ns, zone) {
+					t.addEdge(zone, ns, GlueFor)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Tree) addNode(n *Node) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, found := t.nodes[n.Name]; !found {
+		t.nodes[n.Name] = n
+	}
+}
+
+func (t *Tree) addEdge(from, to string, rel Relation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.edges = append(t.edges, &Edge{From: from, To: to, Rel: rel})
+}
+
+func (t *Tree) zoneCut(zone string) ([]string, error) {
+	if v, ok := t.cached(zone, "NS"); ok {
+		if v == nil {
+			return nil, fmt.Errorf("negative cache hit for %s NS", zone)
+		}
+		return v.([]string), nil
+	}
+
+	_, nss, err := t.resolver.ZoneCut(zone)
+	t.storeCache(zone, "NS", nss, err)
+	return nss, err
+}
+
+func (t *Tree) resolve(name string) ([]string, bool, error) {
+	if v, ok := t.cached(name, "A"); ok {
+		if v == nil {
+			return nil, false, fmt.Errorf("negative cache hit for %s A/AAAA", name)
+		}
+		pair := v.([2]interface{})
+		return pair[0].([]string), pair[1].(bool), nil
+	}
+
+	addrs, followed, err := t.resolver.Resolve(name)
+	if err == nil {
+		t.storeCache(name, "A", [2]interface{}{addrs, followed}, nil)
+	} else {
+		t.storeCache(name, "A", nil, err)
+	}
+	return addrs, followed, err
+}
+
+func (t *Tree) cached(name, qtype string) (interface{}, bool) {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	e, found := t.cache[cacheKey{name, qtype}]
+	if !found || time.Now().After(e.expires) {
+		return nil, false
+	}
+	if e.negative {
+		return nil, true
+	}
+	return e.value, true
+}
+
+func (t *Tree) storeCache(name, qtype string, value interface{}, err error) {
+	ttl := 5 * time.Minute
+	if err != nil {
+		if min, serr := t.resolver.SOAMinimum(t.root); serr == nil {
+			ttl = min
+		}
+	}
+
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+	t.cache[cacheKey{name, qtype}] = &cacheEntry{
+		value:    value,
+		err:      err,
+		negative: err != nil,
+		expires:  time.Now().Add(ttl),
+	}
+}
+
+func inBailiwick(ns, zone string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(ns, "."), strings.TrimSuffix(zone, "."))
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+// DNSProviderCreds holds the credentials needed to authenticate against one hosted DNS
+// provider, as configured via Config.DNSProviders.
+type DNSProviderCreds struct {
+	APIKey string
+	Secret string
+	Tenant string
+}
+
+// dnsProviderService is embedded by every hosted-DNS-provider AmassService. It follows
+// the same OnStart/startRootDomains/processRequests shape as the scrapers (PTRArchive,
+// DNSTable), but listRecords authenticates and lists record sets instead of hitting a
+// scrape URL.
+type dnsProviderService struct {
+	BaseAmassService
+
+	SourceType  string
+	ProviderKey string
+	listRecords func(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error)
+}
+
+func newDNSProviderService(e *Enumeration, name, providerKey string, list func(*DNSProviderCreds, string) ([]*AmassRequest, error)) *dnsProviderService {
+	d := &dnsProviderService{
+		SourceType:  DNSAPI,
+		ProviderKey: providerKey,
+		listRecords: list,
+	}
+	d.BaseAmassService = *NewBaseAmassService(e, name, d)
+	return d
+}
+
+// OnStart implements the AmassService interface
+func (d *dnsProviderService) OnStart() error {
+	d.BaseAmassService.OnStart()
+
+	go d.startRootDomains()
+	go d.processRequests()
+	return nil
+}
+
+// OnStop implements the AmassService interface
+func (d *dnsProviderService) OnStop() error {
+	d.BaseAmassService.OnStop()
+	return nil
+}
+
+func (d *dnsProviderService) processRequests() {
+	for {
+		select {
+		case <-d.PauseChan():
+			<-d.ResumeChan()
+		case <-d.Quit():
+			return
+		case <-d.RequestChan():
+			// This data source just throws away the checked DNS names
+			d.SetActive()
+		}
+	}
+}
+
+func (d *dnsProviderService) startRootDomains() {
+	creds, found := d.Enum().Config.DNSProviders[d.ProviderKey]
+	if !found {
+		// No credentials configured for this provider; nothing to enumerate
+		return
+	}
+
+	for _, domain := range d.Enum().Config.Domains() {
+		d.executeQuery(creds, domain)
+	}
+}
+
+func (d *dnsProviderService) executeQuery(creds *DNSProviderCreds, domain string) {
+	requests, err := d.listRecords(creds, domain)
+	if err != nil {
+		d.Enum().Log.Printf("%s: %s: %v", d.String(), domain, err)
+		return
+	}
+
+	d.SetActive()
+	for _, req := range requests {
+		req.Tag = d.SourceType
+		req.Source = d.String()
+		d.Enum().NewNameEvent(req)
+	}
+}
+
+// NewRoute53 returns the AmassService that lists record sets from hosted zones in
+// Amazon Route53 matching the enumeration's configured root domains.
+func NewRoute53(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "Route53", "route53", listRoute53Records)
+}
+
+// NewCloudflareDNS returns the AmassService that lists DNS records from zones owned in
+// Cloudflare matching the enumeration's configured root domains.
+func NewCloudflareDNS(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "CloudflareDNS", "cloudflare", listCloudflareRecords)
+}
+
+// NewAzureDNS returns the AmassService that lists record sets from zones hosted in
+// Azure DNS matching the enumeration's configured root domains.
+func NewAzureDNS(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "AzureDNS", "azure", listAzureDNSRecords)
+}
+
+// NewDNSimple returns the AmassService that lists records from zones hosted on DNSimple
+// matching the enumeration's configured root domains.
+func NewDNSimple(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "DNSimple", "dnsimple", listDNSimpleRecords)
+}
+
+// NewGoogleCloudDNS returns the AmassService that lists record sets from managed zones
+// hosted in Google Cloud DNS matching the enumeration's configured root domains.
+func NewGoogleCloudDNS(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "GoogleCloudDNS", "googlecloud", listGoogleCloudDNSRecords)
+}
+
+// NewDigitalOceanDNS returns the AmassService that lists records from domains hosted on
+// DigitalOcean matching the enumeration's configured root domains.
+func NewDigitalOceanDNS(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "DigitalOceanDNS", "digitalocean", listDigitalOceanRecords)
+}
+
+// NewGandiDNS returns the AmassService that lists records from zones hosted on Gandi
+// matching the enumeration's configured root domains.
+func NewGandiDNS(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "GandiDNS", "gandi", listGandiRecords)
+}
+
+// NewNS1 returns the AmassService that lists records from zones hosted on NS1 matching
+// the enumeration's configured root domains.
+func NewNS1(e *Enumeration) AmassService {
+	return newDNSProviderService(e, "NS1", "ns1", listNS1Records)
+}
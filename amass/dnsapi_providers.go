@@ -0,0 +1,426 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/OWASP/Amass/amass/utils"
+)
+
+// dnsapiRecordTypes are the RRs each provider lister extracts into AmassRequests.
+var dnsapiRecordTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "TXT": true, "MX": true, "SRV": true, "NS": true,
+}
+
+func dnsapiRequests(domain string, names []string) []*AmassRequest {
+	var reqs []*AmassRequest
+	seen := make(map[string]bool)
+
+	for _, n := range names {
+		n = cleanName(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		reqs = append(reqs, &AmassRequest{Name: n, Domain: domain})
+	}
+	return reqs
+}
+
+// fqdn joins a provider's relative record label with domain, treating "" and the common
+// "@" apex convention as the zone root itself.
+func fqdn(label, domain string) string {
+	if label == "" || label == "@" {
+		return domain
+	}
+	return label + "." + domain
+}
+
+const (
+	route53Region  = "us-east-1"
+	route53Service = "route53"
+)
+
+// route53ZonesResp mirrors the subset of the Route53 ListHostedZonesByName XML response
+// this lister needs to find the hosted zone ID for domain.
+type route53ZonesResp struct {
+	XMLName xml.Name `xml:"ListHostedZonesByNameResponse"`
+	Zones   []struct {
+		ID   string `xml:"Id"`
+		Name string `xml:"Name"`
+	} `xml:"HostedZones>HostedZone"`
+}
+
+// route53ListResp mirrors the subset of the Route53 ListResourceRecordSets XML response
+// this lister needs.
+type route53ListResp struct {
+	XMLName      xml.Name `xml:"ListResourceRecordSetsResponse"`
+	ResourceSets []struct {
+		Name string `xml:"Name"`
+		Type string `xml:"Type"`
+	} `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+func listRoute53Records(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	zoneID, err := route53ZoneIDForDomain(creds, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://route53.amazonaws.com/2013-04-01/hostedzone/%s/rrset", zoneID)
+	page, err := awsSignedGet(creds, url, route53Region, route53Service)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(route53ListResp)
+	if err := xml.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.ResourceSets {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, rr.Name)
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// route53ZoneIDForDomain looks up the hosted zone whose apex matches domain and returns its
+// ID with the "/hostedzone/" path prefix stripped off.
+func route53ZoneIDForDomain(creds *DNSProviderCreds, domain string) (string, error) {
+	listURL := "https://route53.amazonaws.com/2013-04-01/hostedzonesbyname?dnsname=" + domain
+	page, err := awsSignedGet(creds, listURL, route53Region, route53Service)
+	if err != nil {
+		return "", err
+	}
+
+	resp := new(route53ZonesResp)
+	if err := xml.Unmarshal([]byte(page), resp); err != nil {
+		return "", err
+	}
+
+	want := domain + "."
+	for _, z := range resp.Zones {
+		if z.Name == want {
+			return strings.TrimPrefix(z.ID, "/hostedzone/"), nil
+		}
+	}
+	return "", fmt.Errorf("no hosted zone found for %s", domain)
+}
+
+// awsSignedGet issues a GET request signed with AWS Signature Version 4 (SigV4), the scheme
+// every Route53 API call requires regardless of region.
+func awsSignedGet(creds *DNSProviderCreds, rawurl, region, service string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-date:%s\n", u.Host, amzDate)
+	signedHeaders := "host;x-amz-date"
+	payloadHash := sha256Hex(nil)
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, region, service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(creds.Secret, dateStamp, region, service), stringToSign))
+	authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.APIKey, credentialScope, signedHeaders, signature)
+
+	hdr := map[string]string{
+		"Authorization": authHeader,
+		"X-Amz-Date":    amzDate,
+	}
+	return utils.RequestWebPage(rawurl, hdr, nil, "", "", "")
+}
+
+func awsSigningKey(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type cfZoneResp struct {
+	Result []struct {
+		ID string `json:"id"`
+	} `json:"result"`
+}
+
+type cfRecordResp struct {
+	Result []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"result"`
+}
+
+func listCloudflareRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	hdr := map[string]string{
+		"Authorization": "Bearer " + creds.APIKey,
+		"Content-Type":  "application/json",
+	}
+
+	zonePage, err := utils.RequestWebPage("https://api.cloudflare.com/client/v4/zones?name="+domain, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	zones := new(cfZoneResp)
+	if err := json.Unmarshal([]byte(zonePage), zones); err != nil || len(zones.Result) == 0 {
+		return nil, fmt.Errorf("no Cloudflare zone found for %s", domain)
+	}
+
+	recPage, err := utils.RequestWebPage(
+		fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", zones.Result[0].ID),
+		hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	records := new(cfRecordResp)
+	if err := json.Unmarshal([]byte(recPage), records); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range records.Result {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, rr.Name)
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// azureRecordSetListResp mirrors Azure DNS's recordSetListResult: the record sets live
+// under "value", and each one's Type is a fully-qualified ARM resource type
+// (e.g. "Microsoft.Network/dnsZones/A") rather than a bare RR type.
+type azureRecordSetListResp struct {
+	Value []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"value"`
+}
+
+func listAzureDNSRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf(
+		"https://management.azure.com/subscriptions/%s/providers/Microsoft.Network/dnsZones/%s/all?api-version=2018-05-01",
+		creds.Tenant, domain)
+	hdr := map[string]string{"Authorization": "Bearer " + creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(azureRecordSetListResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.Value {
+		if rrType := rr.Type[strings.LastIndex(rr.Type, "/")+1:]; dnsapiRecordTypes[rrType] {
+			names = append(names, rr.Name)
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// dnsimpleRecordsResp mirrors DNSimple's zone records response: the records live under
+// "data", and Name is a label relative to the zone rather than a full name.
+type dnsimpleRecordsResp struct {
+	Data []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"data"`
+}
+
+func listDNSimpleRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf("https://api.dnsimple.com/v2/%s/zones/%s/records", creds.Tenant, domain)
+	hdr := map[string]string{"Authorization": "Bearer " + creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(dnsimpleRecordsResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.Data {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, fqdn(rr.Name, domain))
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// gcpRRSetsResp mirrors Google Cloud DNS's managedZones.rrsets.list response, where the
+// record sets live under "rrsets" and Name is already a fully-qualified, dot-terminated name.
+type gcpRRSetsResp struct {
+	RRSets []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"rrsets"`
+}
+
+func listGoogleCloudDNSRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf("https://dns.googleapis.com/dns/v1/projects/%s/managedZones/%s/rrsets",
+		creds.Tenant, domain)
+	hdr := map[string]string{"Authorization": "Bearer " + creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(gcpRRSetsResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.RRSets {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, rr.Name)
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// doRecordsResp mirrors DigitalOcean's domain records response: the records live under
+// "domain_records", and Name is a label relative to the zone ("@" for the apex).
+type doRecordsResp struct {
+	DomainRecords []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"domain_records"`
+}
+
+func listDigitalOceanRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf("https://api.digitalocean.com/v2/domains/%s/records", domain)
+	hdr := map[string]string{"Authorization": "Bearer " + creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(doRecordsResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.DomainRecords {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, fqdn(rr.Name, domain))
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// gandiRecord mirrors one entry of Gandi LiveDNS's flat records array; the field names are
+// rrset_name/rrset_type rather than name/type, and Name is relative to the zone.
+type gandiRecord struct {
+	Name string `json:"rrset_name"`
+	Type string `json:"rrset_type"`
+}
+
+func listGandiRecords(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf("https://api.gandi.net/v5/livedns/domains/%s/records", domain)
+	hdr := map[string]string{"Authorization": "Bearer " + creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var records []gandiRecord
+	if err := json.Unmarshal([]byte(page), &records); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range records {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, fqdn(rr.Name, domain))
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
+
+// ns1ZoneResp mirrors NS1's zone GET response: it's a zone object, not an array, and its
+// records live under "records" with the name already fully qualified.
+type ns1ZoneResp struct {
+	Records []struct {
+		Domain string `json:"domain"`
+		Type   string `json:"type"`
+	} `json:"records"`
+}
+
+func listNS1Records(creds *DNSProviderCreds, domain string) ([]*AmassRequest, error) {
+	listURL := fmt.Sprintf("https://api.nsone.net/v1/zones/%s", domain)
+	hdr := map[string]string{"X-NSONE-Key": creds.APIKey}
+
+	page, err := utils.RequestWebPage(listURL, hdr, nil, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(ns1ZoneResp)
+	if err := json.Unmarshal([]byte(page), resp); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, rr := range resp.Records {
+		if dnsapiRecordTypes[rr.Type] {
+			names = append(names, rr.Domain)
+		}
+	}
+	return dnsapiRequests(domain, names), nil
+}
@@ -141,11 +141,15 @@ func (d *DNSDumpster) postForm(token, domain string) (string, error) {
 	req.Header.Set("Referer", "https://dnsdumpster.com")
 	req.Header.Set("X-CSRF-Token", token)
 
+	d.Enum().hostLimiter.Wait(req.URL.String())
 	resp, err := client.Do(req)
 	if err != nil {
 		d.Enum().Log.Printf("%s: The POST request failed: %v", d.String(), err)
 		return "", err
 	}
+	if resp.StatusCode == 429 || resp.StatusCode >= 500 {
+		d.Enum().hostLimiter.Penalize(req.URL.String(), retryAfterHeader(resp))
+	}
 	// Now, grab the entire page
 	in, err := ioutil.ReadAll(resp.Body)
 	resp.Body.Close()
@@ -5,8 +5,6 @@ package amass
 
 import (
 	"fmt"
-
-	"github.com/OWASP/Amass/amass/utils"
 )
 
 // Netcraft is the AmassService that handles access to the Netcraft data source.
@@ -62,7 +60,7 @@ func (n *Netcraft) startRootDomains() {
 
 func (n *Netcraft) executeQuery(domain string) {
 	url := n.getURL(domain)
-	page, err := utils.RequestWebPage(url, nil, nil, "", "", n.Enum().Proxy)
+	page, err := n.Enum().RequestWebPage(url, nil, nil)
 	if err != nil {
 		n.Enum().Log.Printf("%s: %s, %v", n.String(), url, err)
 		return
@@ -0,0 +1,205 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is the stable version of the JSONOutput schema, so downstream tooling
+// (SIEMs, notebooks) can pin against a specific shape.
+const SchemaVersion = 1
+
+// OutputFormat selects how Enumeration.Output is rendered by the CLI/consumers.
+type OutputFormat int
+
+// The output formats supported alongside the plain-text default.
+const (
+	Text OutputFormat = iota
+	JSONLines
+	NDJSONGraph
+)
+
+// JSONAddress is a single resolved address entry within a JSONOutput record.
+type JSONAddress struct {
+	IP   string `json:"ip"`
+	CIDR string `json:"cidr"`
+	ASN  int    `json:"asn"`
+	Desc string `json:"desc"`
+}
+
+// JSONGraphEdge describes one edge of the dependency graph touching this name, emitted
+// only when OutputFormat is NDJSONGraph.
+type JSONGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rel  string `json:"rel"`
+}
+
+// JSONOutput is the versioned, schema-stable JSON representation of an AmassOutput record.
+type JSONOutput struct {
+	SchemaVersion int             `json:"schema_version"`
+	Name          string          `json:"name"`
+	Domain        string          `json:"domain"`
+	Addresses     []JSONAddress   `json:"addresses"`
+	Tag           string          `json:"tag"`
+	Sources       []string        `json:"sources"`
+	FirstSeen     time.Time       `json:"first_seen"`
+	LastSeen      time.Time       `json:"last_seen"`
+	GraphEdges    []JSONGraphEdge `json:"graph_edges,omitempty"`
+}
+
+// ToJSONOutput converts an AmassOutput into its versioned JSON representation.
+func ToJSONOutput(out *AmassOutput, format OutputFormat) *JSONOutput {
+	j := &JSONOutput{
+		SchemaVersion: SchemaVersion,
+		Name:          out.Name,
+		Domain:        out.Domain,
+		Tag:           out.Tag,
+		Sources:       out.Sources,
+		FirstSeen:     out.FirstSeen,
+		LastSeen:      out.LastSeen,
+	}
+
+	for _, addr := range out.Addresses {
+		j.Addresses = append(j.Addresses, JSONAddress{
+			IP:   addr.Address.String(),
+			CIDR: addr.Netblock.String(),
+			ASN:  addr.ASN,
+			Desc: addr.Description,
+		})
+	}
+
+	if format == NDJSONGraph {
+		for _, e := range out.GraphEdges {
+			j.GraphEdges = append(j.GraphEdges, JSONGraphEdge{From: e.From, To: e.To, Rel: e.Rel})
+		}
+	}
+	return j
+}
+
+// WriteJSONLines drains e.Output, writing one JSONOutput record per line to w until the
+// channel is closed.
+func (e *Enumeration) WriteJSONLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+
+	for out := range e.Output {
+		if err := enc.Encode(ToJSONOutput(out, e.OutputFormat)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeOutputStream streams each AmassOutput as NDJSON over a listener, so external
+// tools can tail an in-progress enumeration instead of waiting for it to finish. network
+// is "unix" or "tcp"; addr is the socket path or host:port to listen on.
+func (e *Enumeration) ServeOutputStream(network, addr string) error {
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+
+	for out := range e.Output {
+		data, err := json.Marshal(ToJSONOutput(out, e.OutputFormat))
+		if err != nil {
+			continue
+		}
+		data = append(data, '\n')
+
+		mu.Lock()
+		live := conns[:0]
+		for _, conn := range conns {
+			if _, err := conn.Write(data); err != nil {
+				conn.Close()
+				continue
+			}
+			live = append(live, conn)
+		}
+		conns = live
+		mu.Unlock()
+	}
+	return nil
+}
+
+// ServeOutputHTTP exposes the enumeration's output as a chunked NDJSON HTTP response,
+// one connected client per request, on addr.
+func (e *Enumeration) ServeOutputHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+
+		for out := range e.Output {
+			if err := enc.Encode(ToJSONOutput(out, e.OutputFormat)); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	})
+	return http.ListenAndServe(addr, mux)
+}
+
+// Replay consumes a prior JSONL output file and rebuilds a Graph from it for post-hoc
+// analysis, without re-running any enumeration.
+func Replay(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g := NewGraph()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec JSONOutput
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		var addrs []*AmassAddressInfo
+		for _, a := range rec.Addresses {
+			addrs = append(addrs, &AmassAddressInfo{
+				Address: net.ParseIP(a.IP),
+				ASN:     a.ASN,
+			})
+		}
+
+		g.InsertRecord(&AmassOutput{
+			Name:      rec.Name,
+			Domain:    rec.Domain,
+			Tag:       rec.Tag,
+			Sources:   rec.Sources,
+			FirstSeen: rec.FirstSeen,
+			LastSeen:  rec.LastSeen,
+			Addresses: addrs,
+		})
+	}
+	return g, scanner.Err()
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"net"
+	"time"
+)
+
+// AmassAddressInfo describes one IP address associated with a resolved name, along with the
+// netblock/ASN context the address service attaches to it.
+type AmassAddressInfo struct {
+	Address     net.IP
+	Netblock    *net.IPNet
+	ASN         int
+	Description string
+}
+
+// AmassGraphEdge describes one edge of the dependency graph touching a resolved name, emitted
+// alongside an AmassOutput record when OutputFormat is NDJSONGraph.
+type AmassGraphEdge struct {
+	From string
+	To   string
+	Rel  string
+}
+
+// AmassOutput carries everything gathered about one resolved name through Enumeration.Output
+// and the JSONOutput schema. Dependencies is filled by AnalyzeDependencies, and Replay carries
+// Sources/FirstSeen/LastSeen back from a JSONL file; nothing in this package fills Sources,
+// FirstSeen, LastSeen or GraphEdges on live enumeration output, so those stay empty there.
+type AmassOutput struct {
+	Name    string
+	Domain  string
+	Address string
+	Tag     string
+	Source  string
+
+	Addresses []*AmassAddressInfo
+	Sources   []string
+
+	FirstSeen time.Time
+	LastSeen  time.Time
+
+	GraphEdges   []AmassGraphEdge
+	Dependencies *DependencyReport
+}
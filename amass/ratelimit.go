@@ -0,0 +1,151 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retryAfterHeader parses a response's Retry-After header (seconds form) into a
+// Duration, returning zero when absent or unparsable.
+func retryAfterHeader(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+// HostRateLimiter is a per-host token-bucket limiter. Unlike the global MaxFlow
+// semaphore, a slow source hosted on its own domain no longer shares its budget with
+// every other source, and a host that starts returning 429/5xx gets backed off on its
+// own instead of throttling the whole enumeration.
+type HostRateLimiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*hostBucket
+	defaultRate float64
+	overrides   map[string]float64
+}
+
+type hostBucket struct {
+	mu           sync.Mutex
+	tokens       float64
+	rate         float64
+	last         time.Time
+	backoffUntil time.Time
+	backoff      time.Duration
+}
+
+// NewHostRateLimiter builds a limiter whose default per-host rate is derived from the
+// enumeration's timing template, with any per-source overrides from Config.HostRateLimits
+// taking precedence.
+func NewHostRateLimiter(t EnumerationTiming, overrides map[string]float64) *HostRateLimiter {
+	return &HostRateLimiter{
+		buckets:     make(map[string]*hostBucket),
+		defaultRate: t.ToHostRate(),
+		overrides:   overrides,
+	}
+}
+
+// Wait blocks until the bucket for rawurl's host has a token available, honoring any
+// backoff previously triggered by Penalize.
+func (l *HostRateLimiter) Wait(rawurl string) {
+	b := l.bucketFor(rawurl)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if wait := time.Until(b.backoffUntil); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		time.Sleep(time.Duration((1 - b.tokens) / b.rate * float64(time.Second)))
+		b.tokens = 0
+	} else {
+		b.tokens--
+	}
+}
+
+// Penalize exponentially backs off the bucket for rawurl's host after a 429/5xx response,
+// honoring retryAfter when the upstream provided one.
+func (l *HostRateLimiter) Penalize(rawurl string, retryAfter time.Duration) {
+	b := l.bucketFor(rawurl)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.backoff == 0 {
+		b.backoff = time.Second
+	} else {
+		b.backoff *= 2
+	}
+
+	wait := b.backoff
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	b.backoffUntil = time.Now().Add(wait)
+}
+
+func (l *HostRateLimiter) bucketFor(rawurl string) *hostBucket {
+	host := hostOf(rawurl)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, found := l.buckets[host]
+	if !found {
+		rate := l.defaultRate
+		if r, ok := l.overrides[host]; ok {
+			rate = r
+		}
+		b = &hostBucket{tokens: rate, rate: rate, last: time.Now()}
+		l.buckets[host] = b
+	}
+	return b
+}
+
+func hostOf(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	return strings.ToLower(u.Host)
+}
+
+// ToHostRate returns the default per-host requests-per-second budget for the timing
+// template, used to seed HostRateLimiter buckets that have no explicit override.
+func (t EnumerationTiming) ToHostRate() float64 {
+	switch t {
+	case Paranoid:
+		return 0.1
+	case Sneaky:
+		return 1
+	case Polite:
+		return 3
+	case Normal:
+		return 5
+	case Aggressive:
+		return 10
+	case Insane:
+		return 20
+	}
+	return 5
+}
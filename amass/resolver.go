@@ -0,0 +1,110 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Resolver is implemented by every DNS transport the enumeration can route queries through.
+type Resolver interface {
+	// Resolve performs a single query against the upstream and returns the raw answer.
+	Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error)
+
+	// ReverseSweep walks the provided CIDR performing PTR lookups for every address.
+	ReverseSweep(cidr string) ([]*AmassRequest, error)
+
+	// AXFR attempts a zone transfer against the upstream for the given zone.
+	AXFR(zone string) ([]*AmassRequest, error)
+
+	// String identifies the upstream this Resolver talks to (e.g. "tls://1.1.1.1:853").
+	String() string
+
+	// Passive reports whether this transport is suitable for passive-friendly enumeration.
+	Passive() bool
+}
+
+// ResolverPool selects a Resolver for each query and owns the pooled connections beneath it.
+type ResolverPool struct {
+	sync.Mutex
+
+	resolvers []Resolver
+	next      int
+}
+
+// NewResolverPool parses the provided upstream URIs and builds a pool of Resolvers to serve
+// queries from. Supported schemes are udp/tcp (classic), tls (DoT), https (DoH), quic (DoQ),
+// and sdns (DNSCrypt stamps). An empty upstreams list falls back to a single classic resolver.
+func NewResolverPool(upstreams []string) (*ResolverPool, error) {
+	pool := &ResolverPool{}
+
+	if len(upstreams) == 0 {
+		upstreams = []string{"udp://8.8.8.8:53"}
+	}
+
+	for _, up := range upstreams {
+		r, err := newResolver(up)
+		if err != nil {
+			return nil, err
+		}
+		pool.resolvers = append(pool.resolvers, r)
+	}
+	return pool, nil
+}
+
+// PassivePreferred returns a copy of the pool containing only resolvers considered safe for
+// passive enumeration (DoH/DoT/DoQ/DNSCrypt), falling back to the full pool when none qualify.
+func (p *ResolverPool) PassivePreferred() *ResolverPool {
+	filtered := &ResolverPool{}
+
+	for _, r := range p.resolvers {
+		if r.Passive() {
+			filtered.resolvers = append(filtered.resolvers, r)
+		}
+	}
+	if len(filtered.resolvers) == 0 {
+		return p
+	}
+	return filtered
+}
+
+// Next returns the Resolver that should handle the following query, round-robining across
+// the upstreams configured for the pool.
+func (p *ResolverPool) Next() Resolver {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.resolvers) == 0 {
+		return nil
+	}
+	r := p.resolvers[p.next%len(p.resolvers)]
+	p.next++
+	return r
+}
+
+func newResolver(upstream string) (Resolver, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resolver upstream %s: %v", upstream, err)
+	}
+
+	switch u.Scheme {
+	case "", "udp", "tcp":
+		return newUDPResolver(u), nil
+	case "tls":
+		return newDoTResolver(u), nil
+	case "https":
+		return newDoHResolver(u), nil
+	case "quic":
+		return newDoQResolver(u), nil
+	case "sdns":
+		return newDNSCryptResolver(u), nil
+	}
+	return nil, fmt.Errorf("unsupported resolver scheme: %s", u.Scheme)
+}
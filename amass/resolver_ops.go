@@ -0,0 +1,147 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// reverseSweep issues a PTR lookup for every address in the CIDR using the given Resolver.
+func reverseSweep(r Resolver, cidr string) ([]*AmassRequest, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*AmassRequest
+	for ip := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(ip); incIP(ip) {
+		rev, err := dns.ReverseAddr(ip.String())
+		if err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		resp, err := r.Resolve(ctx, rev, dns.TypePTR)
+		cancel()
+		if err != nil || resp == nil {
+			continue
+		}
+
+		for _, a := range resp.Answer {
+			if ptr, ok := a.(*dns.PTR); ok {
+				requests = append(requests, &AmassRequest{
+					Name:    cleanName(ptr.Ptr),
+					Address: ip.String(),
+					Tag:     DNS,
+					Source:  r.String(),
+				})
+			}
+		}
+	}
+	return requests, nil
+}
+
+// axfrTransfer performs a zone transfer over TCP against the provided server address.
+func axfrTransfer(addr, zone string) ([]*AmassRequest, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(dns.Fqdn(zone))
+
+	tr := new(dns.Transfer)
+	envs, err := tr.In(m, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*AmassRequest
+	for env := range envs {
+		if env.Error != nil {
+			continue
+		}
+		for _, rr := range env.RR {
+			if name := nameFromRR(rr); name != "" {
+				requests = append(requests, &AmassRequest{
+					Name:   cleanName(name),
+					Domain: zone,
+					Tag:    AXFR,
+					Source: addr,
+				})
+			}
+		}
+	}
+	return requests, nil
+}
+
+func nameFromRR(rr dns.RR) string {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.Hdr.Name
+	case *dns.AAAA:
+		return v.Hdr.Name
+	case *dns.CNAME:
+		return v.Hdr.Name
+	case *dns.NS:
+		return v.Hdr.Name
+	}
+	return ""
+}
+
+// dohExchange performs a DNS-over-HTTPS query using the RFC 8484 wireformat media type.
+func dohExchange(ctx context.Context, endpoint, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+
+	answer := new(dns.Msg)
+	if err := answer.Unpack(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// doqExchange performs a DNS-over-QUIC (RFC 9250) query. Establishing the QUIC transport is
+// left to the quic-go powered dialer maintained alongside the resolver pool.
+func doqExchange(ctx context.Context, addr, name string, qtype uint16) (*dns.Msg, error) {
+	return quicDialAndExchange(ctx, addr, name, qtype)
+}
+
+// dnsCryptExchange performs a DNSCrypt query against the resolver described by an sdns:// stamp.
+func dnsCryptExchange(ctx context.Context, stamp, name string, qtype uint16) (*dns.Msg, error) {
+	return dnsCryptDialAndExchange(ctx, stamp, name, qtype)
+}
+
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ameshkov/dnscrypt/v2"
+	"github.com/lucas-clemente/quic-go"
+	"github.com/miekg/dns"
+)
+
+const doqALPN = "doq"
+
+const resolverTimeout = 5 * time.Second
+
+// doqSessions caches one QUIC session per upstream address so repeated queries reuse the
+// already-established connection (and its TLS/QUIC handshake) instead of paying for a fresh
+// dial on every lookup; RFC 9250 §4.2 lets a client open as many streams as it wants on a
+// single session, so a DNS message never needs a session of its own.
+var (
+	doqSessionsMu sync.Mutex
+	doqSessions   = make(map[string]quic.Session)
+)
+
+func dialDoQSession(ctx context.Context, addr string) (quic.Session, error) {
+	tlsConf := &tls.Config{NextProtos: []string{doqALPN}}
+
+	session, err := quic.DialAddrContext(ctx, addr, tlsConf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("DoQ dial to %s failed: %v", addr, err)
+	}
+	return session, nil
+}
+
+// doqSession returns the pooled session for addr, dialing and caching a new one if there
+// isn't one yet. The dial happens with the pool locked so two concurrent first-lookups
+// against the same addr can't both dial and leak the loser's session.
+func doqSession(ctx context.Context, addr string) (quic.Session, error) {
+	doqSessionsMu.Lock()
+	defer doqSessionsMu.Unlock()
+
+	if session, ok := doqSessions[addr]; ok {
+		return session, nil
+	}
+
+	session, err := dialDoQSession(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	doqSessions[addr] = session
+	return session, nil
+}
+
+// dropDoQSession evicts addr's pooled session, e.g. after it's found to be stale, so the
+// next query dials a replacement.
+func dropDoQSession(addr string, session quic.Session) {
+	doqSessionsMu.Lock()
+	if doqSessions[addr] == session {
+		delete(doqSessions, addr)
+	}
+	doqSessionsMu.Unlock()
+	session.CloseWithError(0, "")
+}
+
+// quicDialAndExchange performs a single DNS-over-QUIC exchange as described by RFC 9250,
+// reusing a pooled session for addr and opening a fresh bidirectional stream per query.
+func quicDialAndExchange(ctx context.Context, addr, name string, qtype uint16) (*dns.Msg, error) {
+	session, err := doqSession(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := exchangeOnSession(ctx, session, name, qtype)
+	if err != nil {
+		// The pooled session may have gone stale (idle timeout, server restart) at any
+		// point in the exchange; drop it and retry once against a freshly dialed session.
+		dropDoQSession(addr, session)
+		session, err = doqSession(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		return exchangeOnSession(ctx, session, name, qtype)
+	}
+	return resp, nil
+}
+
+func exchangeOnSession(ctx context.Context, session quic.Session, name string, qtype uint16) (*dns.Msg, error) {
+	stream, err := session.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	// RFC 9250 §4.2.1: the DNS message ID on a DoQ stream must be 0 (the stream itself
+	// disambiguates the response); strict servers reject anything else.
+	m.Id = 0
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 9250 §4.2: every DNS message sent over a DoQ stream is prefixed with its length
+	// as a 2-byte unsigned integer in network byte order.
+	req := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(req, uint16(len(packed)))
+	copy(req[2:], packed)
+	if _, err := stream.Write(req); err != nil {
+		return nil, err
+	}
+
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(stream, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	respBuf := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(stream, respBuf); err != nil {
+		return nil, err
+	}
+
+	resp := new(dns.Msg)
+	if err := resp.Unpack(respBuf); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dnsCryptDialAndExchange resolves the resolver described by an sdns:// stamp and performs a
+// single encrypted exchange against it.
+func dnsCryptDialAndExchange(ctx context.Context, stamp, name string, qtype uint16) (*dns.Msg, error) {
+	client := &dnscrypt.Client{Net: "udp", Timeout: resolverTimeout}
+
+	info, err := client.DialStamp(stamp)
+	if err != nil {
+		return nil, fmt.Errorf("DNSCrypt stamp resolution for %s failed: %v", stamp, err)
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	resp, err := client.Exchange(m, info)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// udpResolver talks classic plaintext DNS over UDP, falling back to TCP on truncation.
+type udpResolver struct {
+	addr   string
+	client *dns.Client
+}
+
+func newUDPResolver(u *url.URL) *udpResolver {
+	return &udpResolver{
+		addr:   hostPort(u, "53"),
+		client: &dns.Client{Net: "udp"},
+	}
+}
+
+func (r *udpResolver) String() string  { return "udp://" + r.addr }
+func (r *udpResolver) Passive() bool   { return false }
+func (r *udpResolver) Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	resp, _, err := r.client.ExchangeContext(ctx, m, r.addr)
+	if resp != nil && resp.Truncated {
+		tcp := &dns.Client{Net: "tcp"}
+		resp, _, err = tcp.ExchangeContext(ctx, m, r.addr)
+	}
+	return resp, err
+}
+
+func (r *udpResolver) ReverseSweep(cidr string) ([]*AmassRequest, error) {
+	return reverseSweep(r, cidr)
+}
+
+func (r *udpResolver) AXFR(zone string) ([]*AmassRequest, error) {
+	return axfrTransfer(r.addr, zone)
+}
+
+// dotResolver implements DNS-over-TLS (RFC 7858). Only the dns.Client configuration is
+// pooled; each query dials its own TLS connection, so connections are not reused.
+type dotResolver struct {
+	addr string
+	pool sync.Pool
+}
+
+func newDoTResolver(u *url.URL) *dotResolver {
+	r := &dotResolver{addr: hostPort(u, "853")}
+	r.pool.New = func() interface{} {
+		return &dns.Client{Net: "tcp-tls", TLSConfig: &tls.Config{ServerName: u.Hostname()}}
+	}
+	return r
+}
+
+func (r *dotResolver) String() string { return "tls://" + r.addr }
+func (r *dotResolver) Passive() bool  { return true }
+
+func (r *dotResolver) Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	c := r.pool.Get().(*dns.Client)
+	defer r.pool.Put(c)
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	resp, _, err := c.ExchangeContext(ctx, m, r.addr)
+	return resp, err
+}
+
+func (r *dotResolver) ReverseSweep(cidr string) ([]*AmassRequest, error) { return reverseSweep(r, cidr) }
+func (r *dotResolver) AXFR(zone string) ([]*AmassRequest, error)         { return axfrTransfer(r.addr, zone) }
+
+// dohResolver implements DNS-over-HTTPS (RFC 8484) using the standard wireformat media type.
+type dohResolver struct {
+	endpoint string
+}
+
+func newDoHResolver(u *url.URL) *dohResolver {
+	return &dohResolver{endpoint: u.String()}
+}
+
+func (r *dohResolver) String() string { return r.endpoint }
+func (r *dohResolver) Passive() bool  { return true }
+
+func (r *dohResolver) Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	return dohExchange(ctx, r.endpoint, name, qtype)
+}
+
+func (r *dohResolver) ReverseSweep(cidr string) ([]*AmassRequest, error) { return reverseSweep(r, cidr) }
+func (r *dohResolver) AXFR(zone string) ([]*AmassRequest, error) {
+	return nil, &resolverError{r.endpoint, "AXFR is not available over DoH"}
+}
+
+// doqResolver implements DNS-over-QUIC (RFC 9250) over a pooled, reused QUIC session per
+// upstream address.
+type doqResolver struct {
+	addr string
+}
+
+func newDoQResolver(u *url.URL) *doqResolver {
+	return &doqResolver{addr: hostPort(u, "853")}
+}
+
+func (r *doqResolver) String() string { return "quic://" + r.addr }
+func (r *doqResolver) Passive() bool  { return true }
+
+func (r *doqResolver) Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	return doqExchange(ctx, r.addr, name, qtype)
+}
+
+func (r *doqResolver) ReverseSweep(cidr string) ([]*AmassRequest, error) { return reverseSweep(r, cidr) }
+func (r *doqResolver) AXFR(zone string) ([]*AmassRequest, error) {
+	return nil, &resolverError{r.addr, "AXFR is not available over DoQ"}
+}
+
+// dnsCryptResolver implements the DNSCrypt protocol, configured from an sdns:// stamp.
+type dnsCryptResolver struct {
+	stamp string
+}
+
+func newDNSCryptResolver(u *url.URL) *dnsCryptResolver {
+	return &dnsCryptResolver{stamp: u.String()}
+}
+
+func (r *dnsCryptResolver) String() string { return r.stamp }
+func (r *dnsCryptResolver) Passive() bool  { return true }
+
+func (r *dnsCryptResolver) Resolve(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	return dnsCryptExchange(ctx, r.stamp, name, qtype)
+}
+
+func (r *dnsCryptResolver) ReverseSweep(cidr string) ([]*AmassRequest, error) { return reverseSweep(r, cidr) }
+func (r *dnsCryptResolver) AXFR(zone string) ([]*AmassRequest, error) {
+	return nil, &resolverError{r.stamp, "AXFR is not available over DNSCrypt"}
+}
+
+type resolverError struct {
+	upstream string
+	reason   string
+}
+
+func (e *resolverError) Error() string {
+	return e.upstream + ": " + e.reason
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return u.Hostname() + ":" + defaultPort
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"sort"
+	"strings"
+)
+
+// nameSnapshot is a point-in-time view of every name the enumeration has resolved so far,
+// keyed by name. Graph and StringFilter expose no way to enumerate or diff their state, so
+// Monitor diffs one nameSnapshot against the next instead, and LoadState/persistState save and
+// reload this (plus the plain name lists below) rather than the live Graph/StringFilter
+// objects.
+type nameSnapshot map[string]*AmassOutput
+
+// recordSeen adds or updates out in the enumeration's running snapshot.
+func (e *Enumeration) recordSeen(out *AmassOutput) {
+	e.seenLock.Lock()
+	defer e.seenLock.Unlock()
+
+	if e.seen == nil {
+		e.seen = make(nameSnapshot)
+	}
+	e.seen[out.Name] = out
+}
+
+// copySnapshot returns a point-in-time copy of the running snapshot, safe for the caller to
+// diff against later without racing concurrent OutputEvent calls.
+func (e *Enumeration) copySnapshot() nameSnapshot {
+	e.seenLock.Lock()
+	defer e.seenLock.Unlock()
+
+	cp := make(nameSnapshot, len(e.seen))
+	for k, v := range e.seen {
+		cp[k] = v
+	}
+	return cp
+}
+
+// recordName tracks a newly admitted name alongside trustedNameFilter/otherNameFilter, since
+// those filters only support membership tests and can't be enumerated back out for Store.
+func (e *Enumeration) recordName(trusted bool, name string) {
+	e.nameListLock.Lock()
+	defer e.nameListLock.Unlock()
+
+	if trusted {
+		e.trustedNames = append(e.trustedNames, name)
+	} else {
+		e.otherNames = append(e.otherNames, name)
+	}
+}
+
+// fingerprint summarizes the parts of out that matter for Changed detection, since a name
+// can persist across a Monitor pass while resolving to a different address.
+func fingerprint(out *AmassOutput) string {
+	addrs := make([]string, len(out.Addresses))
+	for i, a := range out.Addresses {
+		addrs[i] = a.Address.String()
+	}
+	sort.Strings(addrs)
+	return out.Tag + "|" + strings.Join(addrs, ",")
+}
+
+// diffSnapshots reports every name that appeared, disappeared, or changed its tag or resolved
+// addresses between two Monitor passes.
+func diffSnapshots(before, after nameSnapshot) []*Change {
+	var changes []*Change
+
+	for name, out := range after {
+		prev, found := before[name]
+		if !found {
+			changes = append(changes, &Change{Type: Added, Name: name})
+		} else if fingerprint(prev) != fingerprint(out) {
+			changes = append(changes, &Change{Type: Changed, Name: name})
+		}
+	}
+	for name := range before {
+		if _, found := after[name]; !found {
+			changes = append(changes, &Change{Type: Removed, Name: name})
+		}
+	}
+	return changes
+}
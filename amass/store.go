@@ -0,0 +1,107 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ChangeType categorizes how a name/address differs from the previous monitoring pass.
+type ChangeType int
+
+// The kinds of change a monitoring pass can report against the stored graph.
+const (
+	Added ChangeType = iota
+	Removed
+	Changed
+)
+
+// Change is a single diff entry produced while comparing a fresh enumeration pass
+// against the persisted graph in --monitor mode.
+type Change struct {
+	Type ChangeType
+	Name string
+}
+
+// Store persists enumeration state as JSON blobs under string keys so a later run can
+// reload it with LoadState. persistState saves the graph records and the trusted/other
+// name lists; the DNS answer cache, wildcard decisions and per-source cursors are not
+// saved through it (the stateKeyDNSCache/Wildcards/Cursors keys are unused).
+type Store interface {
+	// Load populates dst (a pointer) with the JSON previously saved under key, returning
+	// false if nothing has been saved yet.
+	Load(key string, dst interface{}) (bool, error)
+
+	// Save persists v (marshaled as JSON) under key.
+	Save(key string, v interface{}) error
+
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// stateKeys names the blobs a Store persists between runs.
+const (
+	stateKeyGraph        = "graph"
+	stateKeyTrustedNames = "trusted-names"
+	stateKeyOtherNames   = "other-names"
+	stateKeyDNSCache     = "dns-cache"
+	stateKeyWildcards    = "wildcards"
+	stateKeyCursors      = "cursors"
+)
+
+// fileStore is a minimal Store implementation backed by one JSON file per key under
+// Config.StateDir. Bolt/Badger-backed stores can be swapped in by satisfying the same
+// interface without the rest of the package needing to change.
+type fileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore returns a Store that persists each key as its own JSON file under dir.
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (s *fileStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+func (s *fileStore) Load(key string, dst interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *fileStore) Save(key string, v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path(key), data, 0644)
+}
+
+func (s *fileStore) Close() error {
+	return nil
+}
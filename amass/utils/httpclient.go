@@ -0,0 +1,232 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultClient is the shared HTTPClient data-source services should route through so a
+// single misbehaving source can no longer silently drop a whole enumeration.
+var DefaultClient = NewHTTPClient()
+
+// HTTPClient wraps the standard library client with a per-hostname QPS/concurrency limit
+// and transparent retries on 429/5xx/network errors, honoring Retry-After when present.
+type HTTPClient struct {
+	client *http.Client
+
+	MaxRetries int
+
+	mu           sync.Mutex
+	buckets      map[string]*hostLimit
+	proxyClients map[string]*http.Client
+}
+
+type hostLimit struct {
+	mu      sync.Mutex
+	sem     chan struct{}
+	tokens  float64
+	last    time.Time
+	qps     float64
+	backoff time.Time
+}
+
+// NewHTTPClient returns an HTTPClient with reasonable defaults: 2 requests/second and 2
+// concurrent connections per host, retrying up to 3 times.
+func NewHTTPClient() *HTTPClient {
+	return &HTTPClient{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		buckets:    make(map[string]*hostLimit),
+	}
+}
+
+// RequestWebPage performs a GET/POST (method defaults to GET) against rawurl, applying
+// this client's per-host rate limiting and retry/backoff policy, and returns the body.
+func (c *HTTPClient) RequestWebPage(rawurl string, headers map[string]string, cookies []*http.Cookie, method, body, proxy string) (string, error) {
+	if method == "" {
+		method = "GET"
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		var bodyReader io.Reader
+		if body != "" {
+			bodyReader = strings.NewReader(body)
+		}
+
+		req, err := http.NewRequest(method, rawurl, bodyReader)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+
+		resp, err := c.DoWithProxy(req, proxy)
+		if err != nil {
+			lastErr = err
+			time.Sleep(backoffDelay(attempt))
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			resp.Body.Close()
+			c.penalize(rawurl, retryAfter(resp))
+			lastErr = &statusError{rawurl, resp.StatusCode}
+			time.Sleep(backoffDelay(attempt))
+			continue
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(data), err
+	}
+	return "", lastErr
+}
+
+// Do performs req after acquiring a slot in the per-host token bucket/concurrency
+// limiter, leaving retry policy to the caller. It never proxies the request; callers that
+// need to honor a per-request proxy (e.g. RequestWebPage) should use DoWithProxy instead.
+func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return c.DoWithProxy(req, "")
+}
+
+// DoWithProxy behaves like Do, but routes req through proxy (a URL such as
+// "http://127.0.0.1:8080") when one is given instead of dialing directly.
+func (c *HTTPClient) DoWithProxy(req *http.Request, proxy string) (*http.Response, error) {
+	limit := c.limitFor(req.URL)
+	limit.wait()
+
+	limit.sem <- struct{}{}
+	defer func() { <-limit.sem }()
+
+	client, err := c.clientFor(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// clientFor returns the *http.Client that should be used for proxy, building and caching a
+// dedicated client with a proxying Transport the first time proxy is seen.
+func (c *HTTPClient) clientFor(proxy string) (*http.Client, error) {
+	if proxy == "" {
+		return c.client, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cl, found := c.proxyClients[proxy]; found {
+		return cl, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %s: %v", proxy, err)
+	}
+
+	cl := &http.Client{
+		Timeout:   c.client.Timeout,
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)},
+	}
+	if c.proxyClients == nil {
+		c.proxyClients = make(map[string]*http.Client)
+	}
+	c.proxyClients[proxy] = cl
+	return cl, nil
+}
+
+func (c *HTTPClient) limitFor(u *url.URL) *hostLimit {
+	host := strings.ToLower(u.Host)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, found := c.buckets[host]
+	if !found {
+		l = &hostLimit{sem: make(chan struct{}, 2), qps: 2, tokens: 2, last: time.Now()}
+		c.buckets[host] = l
+	}
+	return l
+}
+
+func (c *HTTPClient) penalize(rawurl string, wait time.Duration) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return
+	}
+
+	l := c.limitFor(u)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if wait == 0 {
+		wait = time.Second
+	}
+	l.backoff = time.Now().Add(wait)
+}
+
+func (l *hostLimit) wait() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if d := time.Until(l.backoff); d > 0 {
+		time.Sleep(d)
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.qps
+	if l.tokens > l.qps {
+		l.tokens = l.qps
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		time.Sleep(time.Duration((1 - l.tokens) / l.qps * float64(time.Second)))
+		l.tokens = 0
+	} else {
+		l.tokens--
+	}
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+// backoffDelay returns an exponential backoff with jitter for the given retry attempt.
+func backoffDelay(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt)) * 250 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base) / 2))
+	return base + jitter
+}
+
+type statusError struct {
+	url    string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return e.url + ": unexpected status " + strconv.Itoa(e.status)
+}
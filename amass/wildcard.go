@@ -0,0 +1,244 @@
+// Copyright 2017 Jeff Foley. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package amass
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WildcardDetectionMode trades false-positives against enumeration bloat when deciding
+// whether a parent name resolves via a DNS wildcard.
+type WildcardDetectionMode int
+
+// The wildcard detection modes available via Config.WildcardDetection. Conservative is
+// first (and therefore the zero value) so a Config left unset still filters wildcards,
+// matching the behavior this replaces.
+const (
+	WildcardDetectionConservative WildcardDetectionMode = iota
+	WildcardDetectionOff
+	WildcardDetectionAggressive
+)
+
+const (
+	wildcardSampleSize    = 10
+	wildcardSampleK       = 8
+	wildcardDecisionTTL   = 24 * time.Hour
+	wildcardLabelCharset  = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// wildcardDecision caches whether a (zone, parent) pair was found to be wildcarded, along
+// with the answer-set hash that triggered the decision.
+type wildcardDecision struct {
+	wildcard   bool
+	answerHash string
+	expires    time.Time
+}
+
+// wildcardDetector implements the two-tier Bloom + statistical-sampling wildcard check
+// that replaces the previous per-name resolution-based detection.
+type wildcardDetector struct {
+	mode    WildcardDetectionMode
+	maxFlow int
+
+	mu     sync.Mutex
+	blooms map[string]*bloomFilter
+	cache  map[string]*wildcardDecision
+
+	resolve func(zone, label string) ([]string, string, bool)
+}
+
+func newWildcardDetector(mode WildcardDetectionMode, maxFlow int) *wildcardDetector {
+	return &wildcardDetector{
+		mode:    mode,
+		maxFlow: maxFlow,
+		blooms:  make(map[string]*bloomFilter),
+		cache:   make(map[string]*wildcardDecision),
+	}
+}
+
+// MatchesWildcard reports whether req's name falls under a parent already confirmed, or
+// freshly detected, to answer every query with a wildcard response.
+func (w *wildcardDetector) MatchesWildcard(req *AmassRequest, answerHash string) bool {
+	if w.mode == WildcardDetectionOff {
+		return false
+	}
+
+	parent := parentOf(req.Name, req.Domain)
+	if w.bloomContains(req.Domain, answerHash) {
+		return true
+	}
+	return w.GetWildcardType(req, parent) == WildcardTypeDynamic
+}
+
+// GetWildcardType returns the cached decision for parent, running the statistical sampler
+// the first time a previously unseen parent subdomain is observed. Detection never
+// samples when the mode is Off, so ResolveNameEvent pays nothing extra in that mode.
+func (w *wildcardDetector) GetWildcardType(req *AmassRequest, parent string) WildcardType {
+	if w.mode == WildcardDetectionOff {
+		return WildcardTypeNone
+	}
+
+	key := req.Domain + "|" + parent
+
+	w.mu.Lock()
+	d, found := w.cache[key]
+	w.mu.Unlock()
+
+	if found && time.Now().Before(d.expires) {
+		if d.wildcard {
+			return WildcardTypeDynamic
+		}
+		return WildcardTypeNone
+	}
+
+	wildcard, hash := w.sample(req.Domain, parent)
+
+	w.mu.Lock()
+	w.cache[key] = &wildcardDecision{wildcard: wildcard, answerHash: hash, expires: time.Now().Add(wildcardDecisionTTL)}
+	w.mu.Unlock()
+
+	if wildcard {
+		w.bloomAdd(req.Domain, hash)
+		return WildcardTypeDynamic
+	}
+	return WildcardTypeNone
+}
+
+// sample resolves wildcardSampleSize random non-existent labels under parent and reports
+// a wildcard if at least wildcardSampleK of them collide on the same answer-set hash.
+func (w *wildcardDetector) sample(domain, parent string) (bool, string) {
+	if w.resolve == nil {
+		return false, ""
+	}
+
+	counts := make(map[string]int)
+	var best string
+
+	for i := 0; i < wildcardSampleSize; i++ {
+		label := randomLabel(10)
+
+		answers, hash, ok := w.resolve(parent, label)
+		if !ok || len(answers) == 0 {
+			continue
+		}
+
+		counts[hash]++
+		if counts[hash] > counts[best] {
+			best = hash
+		}
+	}
+
+	threshold := wildcardSampleK
+	if w.mode == WildcardDetectionAggressive {
+		threshold = wildcardSampleK - 2
+	}
+	return counts[best] >= threshold, best
+}
+
+func (w *wildcardDetector) bloomContains(domain, hash string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, found := w.blooms[domain]
+	if !found {
+		return false
+	}
+	return b.Contains(hash)
+}
+
+func (w *wildcardDetector) bloomAdd(domain, hash string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, found := w.blooms[domain]
+	if !found {
+		b = newBloomFilter(w.bloomSize())
+		w.blooms[domain] = b
+	}
+	b.Add(hash)
+}
+
+// bloomSize sizes the per-zone Bloom filter off the enumeration's configured max flow so
+// busier timing templates get a proportionally larger filter.
+func (w *wildcardDetector) bloomSize() int {
+	size := w.maxFlow * 32
+	if size < 1024 {
+		size = 1024
+	}
+	return size
+}
+
+// AnswerHash hashes a sorted set of IPs plus an optional CNAME target into a stable key
+// used both by the Bloom filter and the statistical sampler's collision check.
+func AnswerHash(ips []string, cname string) string {
+	sorted := append([]string{}, ips...)
+	sort.Strings(sorted)
+
+	h := sha1.New()
+	h.Write([]byte(strings.Join(sorted, ",")))
+	h.Write([]byte("|"))
+	h.Write([]byte(cname))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func parentOf(name, domain string) string {
+	name = strings.TrimSuffix(name, "."+domain)
+	labels := strings.Split(name, ".")
+	if len(labels) <= 1 {
+		return domain
+	}
+	return strings.Join(labels[1:], ".") + "." + domain
+}
+
+func randomLabel(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = wildcardLabelCharset[rand.Intn(len(wildcardLabelCharset))]
+	}
+	return fmt.Sprintf("%s", b)
+}
+
+// bloomFilter is a small fixed-size Bloom filter sized at construction time; it is not
+// safe for concurrent use without the caller's own locking (wildcardDetector provides it).
+type bloomFilter struct {
+	bits []bool
+	k    int
+}
+
+func newBloomFilter(size int) *bloomFilter {
+	return &bloomFilter{bits: make([]bool, size), k: 3}
+}
+
+func (b *bloomFilter) Add(item string) {
+	for _, idx := range b.indexes(item) {
+		b.bits[idx] = true
+	}
+}
+
+func (b *bloomFilter) Contains(item string) bool {
+	for _, idx := range b.indexes(item) {
+		if !b.bits[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomFilter) indexes(item string) []int {
+	idxs := make([]int, b.k)
+	h := sha1.Sum([]byte(item))
+
+	for i := 0; i < b.k; i++ {
+		v := uint32(h[i*4])<<24 | uint32(h[i*4+1])<<16 | uint32(h[i*4+2])<<8 | uint32(h[i*4+3])
+		idxs[i] = int(v) % len(b.bits)
+	}
+	return idxs
+}